@@ -0,0 +1,59 @@
+package configurer
+
+import "strings"
+
+// ChangeType describes the kind of structural change a Change represents.
+type ChangeType string
+
+const (
+	ChangeCreate ChangeType = "create"
+	ChangeUpdate ChangeType = "update"
+	ChangeDelete ChangeType = "delete"
+)
+
+// Change describes a single field-level difference between the previous and
+// current configuration, as a stable, typed alternative to IsChanged's
+// dotted-string path.
+type Change struct {
+	// Path is the field's location, one element per nesting level. Slice
+	// elements appear as their numeric index, e.g. []string{"Three", "0",
+	// "Thirty"}.
+	Path []string
+	Type ChangeType
+	From any
+	To   any
+}
+
+// Matches reports whether pattern matches this change's path. Pattern
+// segments are dot-separated and compared one-to-one against Path; "*"
+// matches any single segment, including numeric slice indices, and a
+// trailing ".*" matches any path with that prefix, including the prefix
+// itself. A pattern of just "*" matches every change. For example,
+// "Three.*.Thirty" matches Path []string{"Three", "0", "Thirty"}.
+func (c Change) Matches(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	trailing := strings.HasSuffix(pattern, ".*")
+	segments := strings.Split(strings.TrimSuffix(pattern, ".*"), ".")
+
+	if trailing {
+		if len(segments) > len(c.Path) {
+			return false
+		}
+	} else if len(segments) != len(c.Path) {
+		return false
+	}
+
+	for i, segment := range segments {
+		if segment == "*" {
+			continue
+		}
+		if segment != c.Path[i] {
+			return false
+		}
+	}
+
+	return true
+}
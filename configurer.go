@@ -16,3 +16,23 @@ type ConfigLoader interface {
 	// it should produce an error.
 	Load() (Configuration, error)
 }
+
+// MultiFileLoader is an optional ConfigLoader extension for loaders whose
+// configuration spans more than one file (e.g. a primary config plus
+// included files, or layered base/overlay configs merged into one
+// Configuration). Loaders that don't implement it are treated as depending
+// on just their Filename().
+type MultiFileLoader interface {
+	ConfigLoader
+	// Filenames returns every file path the loader depends on. Notifier
+	// watches all of them for changes.
+	Filenames() []string
+}
+
+// Resolver is an optional ConfigLoader extension for loaders that can only
+// determine the full set of dependent paths after a Load(), e.g. because the
+// configuration references other files from within itself. Notifier calls
+// Resolve after every successful reload and adjusts its watch set to match.
+type Resolver interface {
+	Resolve(Configuration) []string
+}
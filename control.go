@@ -1,22 +1,36 @@
 package configurer
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/r3labs/diff"
 )
 
+// ErrNoPreviousConfig is returned by Control.Rollback when there is no prior
+// commit to roll back to, e.g. it's called before the first reload after
+// New.
+var ErrNoPreviousConfig = errors.New("configurer: no previous configuration to roll back to")
+
+// ErrNoConfigFile is returned when a ConfigLoader has no filename to load
+// from, e.g. New is given a loader whose Filename() is empty, or a
+// WalkUpLoader exhausts its search without finding the file.
+var ErrNoConfigFile = errors.New("configurer: no configuration file found")
+
 // Control keeps track of the current and previous configurations, and a changelog.
 type Control struct {
 	changed  []string
+	changes  []Change
 	loader   ConfigLoader
 	lock     sync.Mutex
 	logger   *slog.Logger
-	current  Configuration
-	previous Configuration
+	current  atomic.Pointer[Configuration]
+	previous atomic.Pointer[Configuration]
+	notifier *Notifier
 }
 
 // New returns a new Control object, or an error.
@@ -44,23 +58,45 @@ func New(loader ConfigLoader, logger *slog.Logger) (*Control, error) {
 }
 
 func (ctrl *Control) readConfig() error {
-	ctrl.lock.Lock()
-	defer ctrl.lock.Unlock()
+	config, err := ctrl.load()
+	if err != nil {
+		return err
+	}
 
+	return ctrl.commit(config)
+}
+
+// load reads a candidate configuration from the loader, without committing
+// it. Notifier uses this to obtain a candidate for Verifier.VerifyConfig
+// before deciding whether to commit it.
+func (ctrl *Control) load() (Configuration, error) {
 	config, err := ctrl.loader.Load()
 	if err != nil {
-		return fmt.Errorf("loading configuration: %w", err)
+		return nil, fmt.Errorf("loading configuration: %w", err)
 	}
 
-	ctrl.previous = ctrl.current
-	ctrl.current = config
+	return config, nil
+}
+
+// commit swaps config in as the current configuration, computing the
+// changelog against the configuration it replaces. current/previous are
+// atomic snapshots so Config and Previous can be read lock-free; the mutex
+// only serializes writers around the diff computation.
+func (ctrl *Control) commit(config Configuration) error {
+	ctrl.lock.Lock()
+	defer ctrl.lock.Unlock()
+
+	previous := ctrl.current.Load()
+	ctrl.previous.Store(previous)
+	ctrl.current.Store(&config)
 
-	if ctrl.previous == nil {
+	if previous == nil {
 		ctrl.changed = []string{"*"}
+		ctrl.changes = nil
 		return nil
 	}
 
-	changelog, err := diff.Diff(ctrl.previous, ctrl.current)
+	changelog, err := diff.Diff(*previous, config)
 	if err != nil {
 		ctrl.logger.Warn("change diff unsuccessful", "error", err)
 		return nil
@@ -68,25 +104,127 @@ func (ctrl *Control) readConfig() error {
 
 	if len(changelog) < 1 {
 		ctrl.changed = []string{}
+		ctrl.changes = nil
 		return nil
 	}
 
 	ctrl.changed = make([]string, 0, len(changelog))
+	ctrl.changes = make([]Change, 0, len(changelog))
 	for _, change := range changelog {
 		ctrl.changed = append(ctrl.changed, strings.Join(change.Path, "."))
+		ctrl.changes = append(ctrl.changes, Change{
+			Path: change.Path,
+			Type: ChangeType(change.Type),
+			From: change.From,
+			To:   change.To,
+		})
 	}
 
 	ctrl.logger.Debug("configuration changed", "changed", ctrl.changed)
 	return nil
 }
 
+// Rollback restores the previous configuration as current, reversing the
+// last commit, and re-notifies every registered Updateable with it if ctrl
+// was built via NewNotifier. This is what makes it safe to call from inside
+// Updateable.UpdateConfig, which only ever receives *Control: a service that
+// fails mid-apply can call ctrl.Rollback() itself to revert the whole
+// cutover, not just its own view of it, without needing a separate
+// reference to the Notifier. Notifier.Rollback is a thin wrapper around this
+// for callers that already hold one.
+//
+// Rollback returns ErrNoPreviousConfig, without touching Config(), if there
+// is no prior commit to roll back to yet (i.e. it's called before the first
+// reload after New).
+func (ctrl *Control) Rollback() error {
+	previous := ctrl.previous.Load()
+	if previous == nil {
+		return ErrNoPreviousConfig
+	}
+
+	if err := ctrl.commit(*previous); err != nil {
+		return err
+	}
+
+	if ctrl.notifier != nil {
+		return ctrl.notifier.Notify()
+	}
+
+	return nil
+}
+
 func (ctrl *Control) filename() string {
 	return ctrl.loader.Filename()
 }
 
-// Config returns the current configuration. It needs to be casted to the final type.
+func (ctrl *Control) filenames() []string {
+	if multi, ok := ctrl.loader.(MultiFileLoader); ok {
+		return multi.Filenames()
+	}
+
+	return []string{ctrl.loader.Filename()}
+}
+
+// Config returns the current configuration. It needs to be casted to the
+// final type.
+//
+// The returned snapshot is immutable from the framework's perspective:
+// callers must not mutate it, since it may be shared with concurrent
+// readers and with Previous() after the next commit.
 func (ctrl *Control) Config() Configuration {
-	return ctrl.current
+	current := ctrl.current.Load()
+	if current == nil {
+		return nil
+	}
+
+	return *current
+}
+
+// Previous returns the configuration that was replaced by the most recent
+// commit, for subscribers that need the pre-change value from within
+// UpdateConfig (e.g. to diff against Config() themselves). Like Config, the
+// returned snapshot is immutable from the framework's perspective.
+func (ctrl *Control) Previous() Configuration {
+	previous := ctrl.previous.Load()
+	if previous == nil {
+		return nil
+	}
+
+	return *previous
+}
+
+// Changes returns the full set of typed, field-level changes from the last
+// reload, as a stable alternative to IsChanged for subscribers that need the
+// before/after values rather than a yes/no answer.
+func (ctrl *Control) Changes() []Change {
+	ctrl.lock.Lock()
+	defer ctrl.lock.Unlock()
+
+	out := make([]Change, len(ctrl.changes))
+	copy(out, ctrl.changes)
+
+	return out
+}
+
+// RangeChanges calls fn for every change from the last reload, stopping
+// early if fn returns false. Prefer this over Changes when you only need to
+// find a match, to avoid allocating a slice for large changelogs.
+//
+// fn is called without ctrl.lock held, so it's safe for fn to call back into
+// IsChanged, Changes, or RangeChanges itself. The lock only protects the
+// snapshot of the slice reference: commit always replaces ctrl.changes with
+// a fresh slice rather than mutating one in place, so the snapshot is safe
+// to range over unlocked even if a concurrent commit runs.
+func (ctrl *Control) RangeChanges(fn func(Change) bool) {
+	ctrl.lock.Lock()
+	changes := ctrl.changes
+	ctrl.lock.Unlock()
+
+	for _, change := range changes {
+		if !fn(change) {
+			return
+		}
+	}
 }
 
 // IsChanged confirms whether a certain portion of the struct has been changed.
@@ -99,6 +237,9 @@ func (ctrl *Control) Config() Configuration {
 // will match: "*", "Database.*", "Database.Connection.*".
 // Note: "*" matches full key, there's no substring matching.
 func (ctrl *Control) IsChanged(item string) bool {
+	ctrl.lock.Lock()
+	defer ctrl.lock.Unlock()
+
 	op := func(entry string) bool { return item == entry }
 	if snippy, ok := strings.CutSuffix(item, ".*"); ok {
 		item = snippy + "."
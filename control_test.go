@@ -1,6 +1,7 @@
 package configurer
 
 import (
+	"errors"
 	"log/slog"
 	"os"
 	"testing"
@@ -35,6 +36,10 @@ func (l *fakeLoader) Filename() string {
 	return "fake"
 }
 
+func (l *fakeLoader) Filenames() []string {
+	return []string{l.Filename()}
+}
+
 func (l *fakeLoader) Load() (Configuration, error) {
 	if l.newAlready {
 		return l.newConfig, nil
@@ -120,3 +125,186 @@ func TestReadConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestChanges(t *testing.T) {
+	gen := func() *fakeConfig {
+		return &fakeConfig{
+			One: "one",
+			Three: []fakeConfigThree{
+				{Thirty: "thirty"},
+			},
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	loader := &fakeLoader{
+		oldConfig: gen(),
+		newConfig: gen(),
+	}
+	loader.newConfig.One = "uno"
+	loader.newConfig.Three[0].Thirty = "30"
+
+	cctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	cctrl.readConfig()
+
+	changes := cctrl.Changes()
+	if len(changes) != 2 {
+		t.Fatalf("Changes() returned %d changes, want 2", len(changes))
+	}
+
+	var sawOne, sawThirty bool
+	for _, change := range changes {
+		if change.Type != ChangeUpdate {
+			t.Errorf("Change(%v).Type = %q, want %q", change.Path, change.Type, ChangeUpdate)
+		}
+		if change.Matches("One") {
+			sawOne = true
+			if change.From != "one" || change.To != "uno" {
+				t.Errorf("One change = %v -> %v, want one -> uno", change.From, change.To)
+			}
+		}
+		if change.Matches("Three.*.Thirty") {
+			sawThirty = true
+		}
+	}
+	if !sawOne {
+		t.Error(`no change matched "One"`)
+	}
+	if !sawThirty {
+		t.Error(`no change matched "Three.*.Thirty"`)
+	}
+
+	var visited int
+	cctrl.RangeChanges(func(Change) bool {
+		visited++
+		return true
+	})
+	if visited != len(changes) {
+		t.Errorf("RangeChanges visited %d changes, want %d", visited, len(changes))
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	gen := func() *fakeConfig {
+		return &fakeConfig{One: "one"}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	loader := &fakeLoader{
+		oldConfig: gen(),
+		newConfig: gen(),
+	}
+	loader.newConfig.One = "two"
+
+	cctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+	if cctrl.Previous() != nil {
+		t.Errorf("Previous() before a second reload = %v, want nil", cctrl.Previous())
+	}
+
+	cctrl.readConfig()
+
+	if got := cctrl.Previous().(*fakeConfig).One; got != "one" {
+		t.Errorf("Previous().One = %q, want %q", got, "one")
+	}
+	if got := cctrl.Config().(*fakeConfig).One; got != "two" {
+		t.Errorf("Config().One = %q, want %q", got, "two")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	gen := func() *fakeConfig {
+		return &fakeConfig{One: "one"}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	loader := &fakeLoader{
+		oldConfig: gen(),
+		newConfig: gen(),
+	}
+	loader.newConfig.One = "two"
+
+	cctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	cctrl.readConfig()
+
+	if got := cctrl.Config().(*fakeConfig).One; got != "two" {
+		t.Fatalf("Config().One = %q, want %q", got, "two")
+	}
+
+	if err := cctrl.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if got := cctrl.Config().(*fakeConfig).One; got != "one" {
+		t.Errorf("Config().One after rollback = %q, want %q", got, "one")
+	}
+	if !cctrl.IsChanged("One") {
+		t.Error("IsChanged(\"One\") after rollback = false, want true")
+	}
+}
+
+func TestRollbackWithNoPreviousConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	loader := &fakeLoader{
+		oldConfig: &fakeConfig{One: "one"},
+		newConfig: &fakeConfig{One: "one"},
+	}
+
+	cctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	if err := cctrl.Rollback(); !errors.Is(err, ErrNoPreviousConfig) {
+		t.Fatalf("Rollback() before any reload = %v, want %v", err, ErrNoPreviousConfig)
+	}
+
+	if got := cctrl.Config().(*fakeConfig).One; got != "one" {
+		t.Errorf("Config().One after rejected rollback = %q, want %q", got, "one")
+	}
+}
+
+// TestConcurrentConfigReadsDuringCommit exercises Config()/Previous() reads
+// racing against commit()'s writes under `go test -race`, which is the
+// race atomic.Pointer is meant to close: before the switch to
+// atomic.Pointer, Config() read the same field commit() wrote under a plain
+// mutex that callers of Config() never took.
+func TestConcurrentConfigReadsDuringCommit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	loader := &fakeLoader{
+		oldConfig: &fakeConfig{One: "one"},
+		newConfig: &fakeConfig{One: "one"},
+	}
+
+	cctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	const iterations = 200
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := 0; i < iterations; i++ {
+			_ = cctrl.commit(&fakeConfig{One: "looping"})
+		}
+	}()
+
+	for i := 0; i < iterations; i++ {
+		_ = cctrl.Config()
+		_ = cctrl.Previous()
+	}
+
+	<-done
+}
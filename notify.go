@@ -4,12 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"math"
+	"path/filepath"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// defaultDebounce is the default coalescing window applied to bursts of
+// filesystem events before triggering a reload. See WithDebounce.
+const defaultDebounce = 200 * time.Millisecond
+
+// relevantOps are the fsnotify operations that can plausibly mean the config
+// file changed: atomic saves show up as Create/Rename of a tmpfile followed
+// by a Rename onto the real path, symlink swaps show up as Create/Remove,
+// and some editors merely Chmod.
+const relevantOps = fsnotify.Create | fsnotify.Rename | fsnotify.Write | fsnotify.Remove | fsnotify.Chmod
+
 // Aborter provides abort notification to spread.
 type Aborter interface {
 	Abort(error)
@@ -22,24 +32,72 @@ type Updateable interface {
 	UpdateConfig(context.Context, *Control) error
 }
 
+// Verifier lets subscribers veto a candidate configuration before it is
+// committed.
+//
+// VerifyConfig is called with the current and candidate configurations
+// before Control swaps them. Returning an error rejects the candidate:
+// Control.Config() and the changelog are left untouched, and none of the
+// Updateable services are notified.
+type Verifier interface {
+	VerifyConfig(ctx context.Context, prev, next Configuration) error
+}
+
 // Notifier tells subsystems about configuration changes.
 type Notifier struct {
-	ctx      context.Context
-	logger   *slog.Logger
-	watcher  *fsnotify.Watcher
-	services []Updateable
-	aborters []Aborter
-	ctrl     *Control
-	wantdown bool
+	ctx            context.Context
+	logger         *slog.Logger
+	watcher        *fsnotify.Watcher
+	services       []Updateable
+	verifiers      []Verifier
+	aborters       []Aborter
+	rejections     chan<- error
+	ctrl           *Control
+	wantdown       bool
+	debounce       time.Duration
+	followSymlinks bool
+	paths          map[string]string // watched path -> resolved real path
+	dirs           map[string]bool   // directories currently registered with the watcher
+}
+
+// NotifierOption customizes a Notifier created by NewNotifier.
+type NotifierOption func(*Notifier)
+
+// WithDebounce sets the window used to coalesce bursts of filesystem events
+// into a single reload. The default is 200ms.
+func WithDebounce(d time.Duration) NotifierOption {
+	return func(notif *Notifier) {
+		notif.debounce = d
+	}
+}
+
+// WithFollowSymlinks controls whether the config path is resolved through
+// symlinks before comparing it against watch events, so that a symlink
+// target swap (k8s ConfigMaps, Helm) is picked up as a change. Enabled by
+// default.
+func WithFollowSymlinks(follow bool) NotifierOption {
+	return func(notif *Notifier) {
+		notif.followSymlinks = follow
+	}
 }
 
 // NewNotifier returns a new notifier object.
-func NewNotifier(ctx context.Context, ctrl *Control, logger *slog.Logger) *Notifier {
-	return &Notifier{
-		ctx:    ctx,
-		ctrl:   ctrl,
-		logger: logger,
+func NewNotifier(ctx context.Context, ctrl *Control, logger *slog.Logger, opts ...NotifierOption) *Notifier {
+	notif := &Notifier{
+		ctx:            ctx,
+		ctrl:           ctrl,
+		logger:         logger,
+		debounce:       defaultDebounce,
+		followSymlinks: true,
 	}
+
+	for _, opt := range opts {
+		opt(notif)
+	}
+
+	ctrl.notifier = notif
+
+	return notif
 }
 
 // RegisterServices adds Updateable services to the list of services to be notified.
@@ -52,6 +110,19 @@ func (notif *Notifier) RegisterAborters(svc ...Aborter) {
 	notif.aborters = append(notif.aborters, svc...)
 }
 
+// RegisterVerifiers adds Verifier services that get a chance to veto a
+// candidate configuration before it is committed.
+func (notif *Notifier) RegisterVerifiers(v ...Verifier) {
+	notif.verifiers = append(notif.verifiers, v...)
+}
+
+// RegisterRejectionChannel sets an optional channel that receives the error
+// returned by a Verifier when it rejects a candidate configuration. Sends are
+// non-blocking: if nothing is reading from ch, the error is dropped.
+func (notif *Notifier) RegisterRejectionChannel(ch chan<- error) {
+	notif.rejections = ch
+}
+
 // Notify sends configuration change notification to Updateable services.
 //
 // This method should be called right after services and aborters registered.
@@ -79,90 +150,248 @@ func (notif *Notifier) Notify() error {
 	return nil
 }
 
-// Watch starts configuration file watching for changes using fsnotify.
+// Rollback restores the configuration Control held before the most recent
+// commit and re-notifies every registered Updateable with it. It's a thin
+// wrapper around Control.Rollback, which already re-notifies on its own
+// since NewNotifier attaches notif to ctrl; an Updateable that only has
+// *Control (as UpdateConfig does) can call ctrl.Rollback() directly from
+// within its own UpdateConfig when it fails mid-apply, reverting the whole
+// cutover via the same Notify path instead of the process aborting
+// outright.
+func (notif *Notifier) Rollback() error {
+	return notif.ctrl.Rollback()
+}
+
+// Run starts configuration file watching for changes using fsnotify, and
+// blocks until ctx is canceled or an unrecoverable error occurs (currently,
+// only a failure setting up the fsnotify watcher). It owns the watcher's
+// entire lifecycle: on return, the fsnotify watcher is guaranteed closed and
+// no further UpdateConfig calls will be issued, so a supervisor (e.g.
+// suture) knows exactly when it's safe to restart by calling Run again with
+// a fresh context.
 //
-// It handles modify and remove events. On removal, it tries to re-add
-// the file to the watchlist immediately, and continues trying with an
-// exponential backoff (starting with 1/2 seconds, with a multiplier of 1.5,
-// backing off after 10 tries).
+// Rather than watching the config files themselves, it watches their parent
+// directories, so it notices atomic saves (editor "save" via tmpfile+rename),
+// symlink target changes (k8s ConfigMaps, Helm), and file removals without
+// needing to re-add a watch. Bursts of events are coalesced with the
+// Notifier's debounce window (see WithDebounce) into a single reload.
 //
-// Watch can be canceled by calling cancelFunc of the provided context.
-func (notif *Notifier) Watch() error {
+// The set of watched paths comes from the loader's Filenames(), plus
+// whatever an optional Resolver hook declares for the current configuration;
+// it's recomputed after every reload, so paths appearing or disappearing
+// from the config are picked up.
+func (notif *Notifier) Run(ctx context.Context) error {
+	notif.ctx = ctx
+
+	if err := notif.setup(); err != nil {
+		return err
+	}
+
+	return notif.watch()
+}
+
+// setup creates the fsnotify watcher and adds the loader's watched
+// directories to it, leaving notif ready for watch(). It's split out of Run
+// so Watch can surface setup failures synchronously while still running the
+// blocking event loop in a goroutine.
+func (notif *Notifier) setup() error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf("setting up new watcher for config: %w", err)
 	}
-
 	notif.watcher = watcher
-	if err = watcher.Add(notif.ctrl.filename()); err != nil {
-		return fmt.Errorf("adding config file name to watcher: %w", err)
+	notif.dirs = nil
+
+	if err := notif.syncWatchPaths(); err != nil {
+		notif.watcher.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Watch is a non-blocking wrapper around Run kept for back-compat: it sets
+// up the fsnotify watcher synchronously, so callers can detect a broken
+// watcher (e.g. fsnotify.NewWatcher failing, or the initial directory add
+// failing) immediately, and only then spawns the blocking event loop in a
+// goroutine using the context Notifier was constructed with, logging the
+// error watch() returns instead of propagating it to the caller. Watch can
+// be canceled by calling cancelFunc of that context. Prefer Run directly
+// when the caller also wants to know when the watcher has stopped.
+func (notif *Notifier) Watch() error {
+	if err := notif.setup(); err != nil {
+		return err
 	}
 
-	go notif.watch()
+	go func() {
+		if err := notif.watch(); err != nil {
+			notif.logger.Warn("watcher stopped", "error", err)
+		}
+	}()
 
 	return nil
 }
 
-func (notif *Notifier) watch() {
+func (notif *Notifier) watch() error {
 	defer func() {
 		notif.logger.Info("watcher finished")
 		notif.watcher.Close()
 	}()
 
+	var debounceTimer *time.Timer
+	var debounceC <-chan time.Time
+
 	for {
 		select {
 		case event, ok := <-notif.watcher.Events:
 			if !ok {
-				return
+				return nil
+			}
+			if !notif.isRelevant(event) {
+				continue
 			}
-			if event.Has(fsnotify.Write) {
-				notif.modify(event)
-			} else if event.Has(fsnotify.Remove) {
-				notif.replace(event)
+			notif.logger.Debug("configuration candidate event", "filename", event.Name, "op", event.Op)
+			if debounceTimer != nil {
+				debounceTimer.Stop()
 			}
+			debounceTimer = time.NewTimer(notif.debounce)
+			debounceC = debounceTimer.C
+		case <-debounceC:
+			debounceC = nil
+			if err := notif.reload(); err != nil {
+				notif.logger.Warn("error reloading config", "error", err)
+				continue
+			}
+			if err := notif.syncWatchPaths(); err != nil {
+				notif.logger.Warn("error updating config watch set", "error", err)
+			}
+			_ = notif.Notify()
 		case err, ok := <-notif.watcher.Errors:
 			if !ok {
-				return
+				return nil
 			}
 			notif.logger.Warn("fsnotify error", "error", err)
 		case <-notif.ctx.Done():
-			return
+			return nil
 		}
 	}
 }
 
-func (notif *Notifier) modify(event fsnotify.Event) {
-	notif.logger.Debug("configuration modified", "filename", event.Name)
-	if err := notif.ctrl.readConfig(); err != nil {
-		notif.logger.Warn("error reloading config", "error", err)
+// syncWatchPaths recomputes the set of paths Notifier cares about (the
+// loader's Filenames() plus whatever an optional Resolver declares for the
+// current configuration), follows symlinks when WithFollowSymlinks is
+// enabled, and brings the fsnotify watcher's directory list in line with it,
+// adding newly-relevant directories and removing ones nothing cares about
+// anymore.
+func (notif *Notifier) syncWatchPaths() error {
+	desired := append([]string{}, notif.ctrl.filenames()...)
+	if resolver, ok := notif.ctrl.loader.(Resolver); ok {
+		desired = append(desired, resolver.Resolve(notif.ctrl.Config())...)
 	}
-	_ = notif.Notify()
-}
 
-func (notif *Notifier) replace(event fsnotify.Event) {
-	notif.logger.Debug("configuration modified", "filename", event.Name)
-	notif.readdWatcher(0)()
+	paths := make(map[string]string, len(desired))
+	dirs := make(map[string]bool, len(desired))
+	for _, path := range desired {
+		if path == "" {
+			continue
+		}
+		real := ""
+		if notif.followSymlinks {
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				real = resolved
+			}
+		}
+		paths[filepath.Clean(path)] = real
+		dirs[filepath.Dir(path)] = true
+		if real != "" {
+			dirs[filepath.Dir(real)] = true
+		}
+	}
 
-	if err := notif.ctrl.readConfig(); err != nil {
-		notif.logger.Warn("error reloading config", "error", err)
+	for dir := range dirs {
+		if notif.dirs[dir] {
+			continue
+		}
+		if err := notif.watcher.Add(dir); err != nil {
+			return fmt.Errorf("adding config directory %q to watcher: %w", dir, err)
+		}
+	}
+	for dir := range notif.dirs {
+		if dirs[dir] {
+			continue
+		}
+		_ = notif.watcher.Remove(dir)
 	}
-	_ = notif.Notify()
+
+	notif.paths = paths
+	notif.dirs = dirs
+
+	return nil
 }
 
-func (notif *Notifier) readdWatcher(attempt int) func() {
-	baseDelay := 500 * time.Millisecond
-	multiplier := 1.5
-	delay := baseDelay * time.Duration(int64(math.Pow(float64(multiplier), float64(attempt))))
+// isRelevant reports whether event affects a watched path or its resolved
+// real path, with an operation that could plausibly mean its contents
+// changed.
+//
+// A Kubernetes ConfigMap/Helm mount never touches the config file itself:
+// kubelet atomically swaps a "..data" symlink in the same directory to point
+// at a new "..data_<timestamp>" directory, and the config path is a symlink
+// through "..data" to the real file two levels down. The event fsnotify
+// delivers for that swap names "..data" or the timestamped directory, not
+// the config path or the real path cached at the last sync. So besides the
+// direct name match, isRelevant re-resolves each watched path's symlink
+// chain and treats a change in its resolved target as relevant too - the
+// same trick viper's WatchConfig uses for this case.
+func (notif *Notifier) isRelevant(event fsnotify.Event) bool {
+	if !event.Has(relevantOps) {
+		return false
+	}
 
-	return func() {
-		if err := notif.watcher.Add(notif.ctrl.filename()); err != nil {
-			if attempt > 10 {
-				notif.logger.Warn("error re-adding config file watcher; disable watching", "error", err)
-				return
+	name := filepath.Clean(event.Name)
+	for path, real := range notif.paths {
+		if name == path || (real != "" && name == real) {
+			return true
+		}
+	}
 
+	if notif.followSymlinks {
+		for path, real := range notif.paths {
+			resolved, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				continue
+			}
+			if resolved != real {
+				return true
 			}
-			notif.logger.Warn("error re-adding config file watcher", "error", err)
-			time.AfterFunc(delay, notif.readdWatcher(attempt+1))
 		}
 	}
+
+	return false
+}
+
+// reload loads a candidate configuration and runs it past every registered
+// Verifier before committing it to ctrl. If any verifier rejects the
+// candidate, ctrl is left untouched, the rejection is logged and optionally
+// sent to the rejection channel, and aborters are not triggered.
+func (notif *Notifier) reload() error {
+	candidate, err := notif.ctrl.load()
+	if err != nil {
+		return err
+	}
+
+	prev := notif.ctrl.Config()
+	for _, verifier := range notif.verifiers {
+		if err := verifier.VerifyConfig(notif.ctx, prev, candidate); err != nil {
+			notif.logger.Warn("configuration rejected by verifier", "error", err)
+			if notif.rejections != nil {
+				select {
+				case notif.rejections <- err:
+				default:
+				}
+			}
+			return err
+		}
+	}
+
+	return notif.ctrl.commit(candidate)
 }
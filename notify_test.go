@@ -0,0 +1,505 @@
+package configurer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type notifyFakeLoader struct {
+	configs []Configuration
+	next    int
+}
+
+func (l *notifyFakeLoader) Filename() string {
+	return "fake"
+}
+
+func (l *notifyFakeLoader) Load() (Configuration, error) {
+	config := l.configs[l.next]
+	if l.next < len(l.configs)-1 {
+		l.next++
+	}
+	return config, nil
+}
+
+type recordingService struct {
+	configs []Configuration
+}
+
+func (s *recordingService) UpdateConfig(_ context.Context, ctrl *Control) error {
+	s.configs = append(s.configs, ctrl.Config())
+	return nil
+}
+
+// fileLoader is a minimal, real file-backed ConfigLoader used to exercise
+// Notifier's fsnotify-driven behavior against an actual filesystem.
+type fileLoader struct {
+	path string
+}
+
+func (l *fileLoader) Filename() string {
+	return l.path
+}
+
+func (l *fileLoader) Load() (Configuration, error) {
+	content, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(content), nil
+}
+
+type rejectingVerifier struct {
+	err error
+}
+
+func (v *rejectingVerifier) VerifyConfig(_ context.Context, _, _ Configuration) error {
+	return v.err
+}
+
+func newTestNotifier(t *testing.T, loader ConfigLoader) (*Control, *Notifier) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	return ctrl, NewNotifier(context.Background(), ctrl, logger)
+}
+
+func TestNotifierRollback(t *testing.T) {
+	loader := &notifyFakeLoader{configs: []Configuration{"one", "two"}}
+	ctrl, notif := newTestNotifier(t, loader)
+
+	svc := &recordingService{}
+	notif.RegisterServices(svc)
+
+	if err := ctrl.readConfig(); err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if err := notif.Notify(); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if got := ctrl.Config(); got != "two" {
+		t.Fatalf("Config() = %v, want %q", got, "two")
+	}
+
+	if err := notif.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if got := ctrl.Config(); got != "one" {
+		t.Errorf("Config() after Rollback = %v, want %q", got, "one")
+	}
+
+	want := []Configuration{"two", "one"}
+	if len(svc.configs) != len(want) {
+		t.Fatalf("UpdateConfig called with %v, want %v", svc.configs, want)
+	}
+	for i, config := range want {
+		if svc.configs[i] != config {
+			t.Errorf("UpdateConfig call %d = %v, want %v", i, svc.configs[i], config)
+		}
+	}
+}
+
+func TestRunStopsOnContextCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("one"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	_, notif := newTestNotifier(t, &fileLoader{path: path})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- notif.Run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after context cancellation")
+	}
+
+	if notif.watcher == nil {
+		t.Fatal("expected watcher to have been set up")
+	}
+	if err := notif.watcher.Add(t.TempDir()); err == nil {
+		t.Error("expected watcher to be closed after Run returned")
+	}
+}
+
+func TestWatchSurfacesSetupErrorsSynchronously(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("one"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	_, notif := newTestNotifier(t, &fileLoader{path: path})
+
+	// Point the watcher at a directory that doesn't exist so syncWatchPaths'
+	// initial watcher.Add fails, exercising the same setup error path as a
+	// broken fsnotify.NewWatcher.
+	notif.paths = nil
+	notif.ctrl.loader = &fileLoader{path: filepath.Join(t.TempDir(), "missing", "config.yaml")}
+
+	if err := notif.Watch(); err == nil {
+		t.Fatal("expected Watch to surface the setup error synchronously")
+	}
+}
+
+func TestReloadRejectedByVerifierLeavesControlUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("one"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	ctrl, notif := newTestNotifier(t, &fileLoader{path: path})
+
+	svc := &recordingService{}
+	notif.RegisterServices(svc)
+	notif.RegisterVerifiers(&rejectingVerifier{err: errors.New("rejected")})
+
+	if err := os.WriteFile(path, []byte("two"), 0o644); err != nil {
+		t.Fatalf("updating config: %v", err)
+	}
+
+	// Mirror the watch loop's own control flow (see watch()): it only calls
+	// Notify when reload succeeds, so a rejected reload must never reach it.
+	if err := notif.reload(); err == nil {
+		t.Fatal("expected reload to be rejected by the verifier")
+	}
+
+	if got := ctrl.Config(); got != "one" {
+		t.Errorf("Config() after rejected reload = %v, want %q", got, "one")
+	}
+	if changes := ctrl.Changes(); len(changes) != 0 {
+		t.Errorf("Changes() after rejected reload = %v, want none", changes)
+	}
+	if len(svc.configs) != 0 {
+		t.Errorf("UpdateConfig called %d times after rejected reload, want 0", len(svc.configs))
+	}
+}
+
+type rollbackOnceService struct {
+	triggered bool
+}
+
+func (s *rollbackOnceService) UpdateConfig(_ context.Context, ctrl *Control) error {
+	if s.triggered {
+		return nil
+	}
+	s.triggered = true
+
+	return ctrl.Rollback()
+}
+
+func TestControlRollbackFromUpdateConfigRenotifiesOtherServices(t *testing.T) {
+	loader := &notifyFakeLoader{configs: []Configuration{"one", "two"}}
+	ctrl, notif := newTestNotifier(t, loader)
+
+	other := &recordingService{}
+	rollbacker := &rollbackOnceService{}
+	notif.RegisterServices(other, rollbacker)
+
+	if err := ctrl.readConfig(); err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if err := notif.Notify(); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if got := ctrl.Config(); got != "one" {
+		t.Fatalf("Config() = %v, want %q", got, "one")
+	}
+
+	want := []Configuration{"two", "one"}
+	if len(other.configs) != len(want) {
+		t.Fatalf("other service saw UpdateConfig with %v, want %v", other.configs, want)
+	}
+	for i, config := range want {
+		if other.configs[i] != config {
+			t.Errorf("other service's UpdateConfig call %d = %v, want %v", i, other.configs[i], config)
+		}
+	}
+}
+
+type resolvingFileLoader struct {
+	fileLoader
+	extra []string
+}
+
+func (l *resolvingFileLoader) Resolve(Configuration) []string {
+	return l.extra
+}
+
+func TestSyncWatchPathsTracksResolver(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(primary, []byte("one"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	extra := filepath.Join(dir, "extra.yaml")
+	if err := os.WriteFile(extra, []byte("extra"), 0o644); err != nil {
+		t.Fatalf("writing extra file: %v", err)
+	}
+
+	loader := &resolvingFileLoader{fileLoader: fileLoader{path: primary}}
+	_, notif := newTestNotifier(t, loader)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("creating watcher: %v", err)
+	}
+	defer watcher.Close()
+	notif.watcher = watcher
+
+	if err := notif.syncWatchPaths(); err != nil {
+		t.Fatalf("syncWatchPaths: %v", err)
+	}
+	if _, ok := notif.paths[primary]; !ok {
+		t.Errorf("expected %s to be watched", primary)
+	}
+	if _, ok := notif.paths[extra]; ok {
+		t.Errorf("did not expect %s to be watched before Resolve declared it", extra)
+	}
+
+	loader.extra = []string{extra}
+	if err := notif.syncWatchPaths(); err != nil {
+		t.Fatalf("syncWatchPaths after Resolve: %v", err)
+	}
+	if _, ok := notif.paths[extra]; !ok {
+		t.Errorf("expected %s to be watched after Resolve declared it", extra)
+	}
+
+	loader.extra = nil
+	if err := notif.syncWatchPaths(); err != nil {
+		t.Fatalf("syncWatchPaths after Resolve stopped declaring extra: %v", err)
+	}
+	if _, ok := notif.paths[extra]; ok {
+		t.Errorf("expected %s to be dropped after Resolve stopped declaring it", extra)
+	}
+}
+
+// countingLoader wraps fileLoader to count how many times Load is actually
+// called, so tests can assert a burst of fs events coalesced into a single
+// reload instead of one per event.
+type countingLoader struct {
+	fileLoader
+	loads int32
+}
+
+func (l *countingLoader) Load() (Configuration, error) {
+	atomic.AddInt32(&l.loads, 1)
+	return l.fileLoader.Load()
+}
+
+func TestDebounceCoalescesBurstIntoOneReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("0"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	loader := &countingLoader{fileLoader: fileLoader{path: path}}
+	ctrl, err := New(loader, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notif := NewNotifier(ctx, ctrl, logger, WithDebounce(80*time.Millisecond))
+	done := make(chan error, 1)
+	go func() { done <- notif.Run(ctx) }()
+
+	// Let the watcher finish setup before generating events.
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt32(&loader.loads, 0)
+
+	for i := 1; i <= 5; i++ {
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("%d", i)), 0o644); err != nil {
+			t.Fatalf("writing config: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Wait past the debounce window for the coalesced reload to fire.
+	time.Sleep(250 * time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+
+	if got := atomic.LoadInt32(&loader.loads); got != 1 {
+		t.Errorf("Load called %d times for a debounced burst, want 1", got)
+	}
+	if got := ctrl.Config(); got != "5" {
+		t.Errorf("Config() = %v, want %q", got, "5")
+	}
+}
+
+func TestFollowSymlinksReloadsOnTargetSwap(t *testing.T) {
+	dir := t.TempDir()
+	targetA := filepath.Join(dir, "a.yaml")
+	targetB := filepath.Join(dir, "b.yaml")
+	if err := os.WriteFile(targetA, []byte("a"), 0o644); err != nil {
+		t.Fatalf("writing target a: %v", err)
+	}
+	if err := os.WriteFile(targetB, []byte("b"), 0o644); err != nil {
+		t.Fatalf("writing target b: %v", err)
+	}
+
+	link := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(targetA, link); err != nil {
+		t.Fatalf("creating symlink: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl, err := New(&fileLoader{path: link}, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+	if got := ctrl.Config(); got != "a" {
+		t.Fatalf("Config() = %v, want %q", got, "a")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notif := NewNotifier(ctx, ctrl, logger, WithDebounce(30*time.Millisecond), WithFollowSymlinks(true))
+	done := make(chan error, 1)
+	go func() { done <- notif.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// Atomically swap the symlink to point at targetB, the way k8s/Helm
+	// update a mounted ConfigMap.
+	tmpLink := link + ".tmp"
+	if err := os.Symlink(targetB, tmpLink); err != nil {
+		t.Fatalf("creating replacement symlink: %v", err)
+	}
+	if err := os.Rename(tmpLink, link); err != nil {
+		t.Fatalf("swapping symlink: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && ctrl.Config() != "b" {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+
+	if got := ctrl.Config(); got != "b" {
+		t.Errorf("Config() after symlink swap = %v, want %q", got, "b")
+	}
+}
+
+// TestFollowSymlinksReloadsOnDataDirSwap reproduces the actual layout a
+// Kubernetes ConfigMap/Secret volume mount uses, which is two levels of
+// indirection deeper than a bare symlink swap: the config path is a symlink
+// through a "..data" symlink to a timestamped directory holding the real
+// file, and kubelet updates the mount by atomically repointing "..data" at a
+// new timestamped directory rather than ever touching the config path or
+// the file it ultimately resolves to.
+func TestFollowSymlinksReloadsOnDataDirSwap(t *testing.T) {
+	dir := t.TempDir()
+
+	tsA := filepath.Join(dir, "..2024_01_01")
+	if err := os.Mkdir(tsA, 0o755); err != nil {
+		t.Fatalf("creating %s: %v", tsA, err)
+	}
+	if err := os.WriteFile(filepath.Join(tsA, "app.yaml"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("writing target a: %v", err)
+	}
+
+	data := filepath.Join(dir, "..data")
+	if err := os.Symlink(tsA, data); err != nil {
+		t.Fatalf("creating ..data symlink: %v", err)
+	}
+
+	link := filepath.Join(dir, "app.yaml")
+	if err := os.Symlink(filepath.Join("..data", "app.yaml"), link); err != nil {
+		t.Fatalf("creating config symlink: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctrl, err := New(&fileLoader{path: link}, logger)
+	if err != nil {
+		t.Fatalf("creating controller: %v", err)
+	}
+	if got := ctrl.Config(); got != "a" {
+		t.Fatalf("Config() = %v, want %q", got, "a")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notif := NewNotifier(ctx, ctrl, logger, WithDebounce(30*time.Millisecond), WithFollowSymlinks(true))
+	done := make(chan error, 1)
+	go func() { done <- notif.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	tsB := filepath.Join(dir, "..2024_01_02")
+	if err := os.Mkdir(tsB, 0o755); err != nil {
+		t.Fatalf("creating %s: %v", tsB, err)
+	}
+	if err := os.WriteFile(filepath.Join(tsB, "app.yaml"), []byte("b"), 0o644); err != nil {
+		t.Fatalf("writing target b: %v", err)
+	}
+
+	// Swap ..data to point at the new timestamped directory the way kubelet
+	// does: create a temporary symlink next to it, then rename it over
+	// ..data. app.yaml itself is never touched.
+	tmpData := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(tsB, tmpData); err != nil {
+		t.Fatalf("creating replacement ..data symlink: %v", err)
+	}
+	if err := os.Rename(tmpData, data); err != nil {
+		t.Fatalf("swapping ..data symlink: %v", err)
+	}
+	_ = os.RemoveAll(tsA)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && ctrl.Config() != "b" {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+
+	if got := ctrl.Config(); got != "b" {
+		t.Errorf("Config() after ..data swap = %v, want %q", got, "b")
+	}
+}
@@ -0,0 +1,81 @@
+package configurer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WalkUpLoader implements ConfigLoader by walking upward from a starting
+// directory through its ancestors until it finds the first one containing
+// filename, the way LSP-style tools discover project-scoped configuration.
+//
+// Load re-runs the walk every time, so creating a config file in a closer
+// ancestor takes over on the next reload, and removing the current match
+// falls back to the next ancestor up. Filename reports the path Load last
+// resolved to.
+type WalkUpLoader struct {
+	startDir string
+	filename string
+	load     func(string) (Configuration, error)
+	resolved string
+}
+
+// NewWalkUpLoader returns a WalkUpLoader that walks up from startDir looking
+// for a file named filename, loading whatever it finds with load.
+func NewWalkUpLoader(startDir, filename string, load func(string) (Configuration, error)) *WalkUpLoader {
+	return &WalkUpLoader{
+		startDir: startDir,
+		filename: filename,
+		load:     load,
+	}
+}
+
+// Filename returns the path Load last resolved to, or the candidate path at
+// startDir if Load hasn't run yet.
+func (l *WalkUpLoader) Filename() string {
+	if l.resolved != "" {
+		return l.resolved
+	}
+
+	return filepath.Join(l.startDir, l.filename)
+}
+
+// Filenames returns every candidate path along the walk, from startDir up to
+// the filesystem root, so Notifier watches the whole ancestor chain: a match
+// can appear or disappear at any level.
+func (l *WalkUpLoader) Filenames() []string {
+	paths := make([]string, 0)
+	for dir := l.startDir; ; {
+		paths = append(paths, filepath.Join(dir, l.filename))
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+		dir = parent
+	}
+}
+
+// Load walks up from startDir and loads the first matching file it finds.
+func (l *WalkUpLoader) Load() (Configuration, error) {
+	for dir := l.startDir; ; {
+		candidate := filepath.Join(dir, l.filename)
+		if _, err := os.Stat(candidate); err == nil {
+			config, err := l.load(candidate)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %w", candidate, err)
+			}
+			l.resolved = candidate
+			return config, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	l.resolved = ""
+	return nil, fmt.Errorf("%s: %w", l.filename, ErrNoConfigFile)
+}
@@ -0,0 +1,73 @@
+package configurer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalkUpLoader(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "child")
+	grandchild := filepath.Join(child, "grandchild")
+	if err := os.MkdirAll(grandchild, 0o755); err != nil {
+		t.Fatalf("creating tree: %v", err)
+	}
+
+	rootConfig := filepath.Join(root, ".myapp.yaml")
+	if err := os.WriteFile(rootConfig, []byte("root"), 0o644); err != nil {
+		t.Fatalf("writing root config: %v", err)
+	}
+
+	load := func(path string) (Configuration, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return string(content), nil
+	}
+
+	loader := NewWalkUpLoader(grandchild, ".myapp.yaml", load)
+
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config != "root" {
+		t.Errorf("Load() = %q, want %q", config, "root")
+	}
+	if loader.Filename() != rootConfig {
+		t.Errorf("Filename() = %q, want %q", loader.Filename(), rootConfig)
+	}
+
+	childConfig := filepath.Join(child, ".myapp.yaml")
+	if err := os.WriteFile(childConfig, []byte("child"), 0o644); err != nil {
+		t.Fatalf("writing child config: %v", err)
+	}
+
+	config, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Load after closer match: %v", err)
+	}
+	if config != "child" {
+		t.Errorf("Load() = %q, want %q", config, "child")
+	}
+	if loader.Filename() != childConfig {
+		t.Errorf("Filename() = %q, want %q", loader.Filename(), childConfig)
+	}
+
+	if err := os.Remove(childConfig); err != nil {
+		t.Fatalf("removing child config: %v", err)
+	}
+
+	config, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Load after removal: %v", err)
+	}
+	if config != "root" {
+		t.Errorf("Load() after removal = %q, want %q", config, "root")
+	}
+	if loader.Filename() != rootConfig {
+		t.Errorf("Filename() after removal = %q, want %q", loader.Filename(), rootConfig)
+	}
+}